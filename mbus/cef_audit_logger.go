@@ -0,0 +1,33 @@
+package mbus
+
+import (
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// cefAuditLogger produces the CEF audit trail shared by every mbus
+// transport (NATS, HTTPS, gRPC, ...), so each handler only needs to know
+// its own request/response shape.
+type cefAuditLogger struct {
+	auditLogger boshplatform.AuditLogger
+	logger      boshlog.Logger
+	logTag      string
+}
+
+func (c cefAuditLogger) Log(host, port, replyTo, method string, severity int, subject, statusReason string) {
+	cef := boshhandler.NewCommonEventFormat()
+
+	cefString, err := cef.ProduceNATSRequestEventLog(host, port, replyTo, method, severity, subject, statusReason)
+	if err != nil {
+		c.logger.Error(c.logTag, err.Error())
+		return
+	}
+
+	if severity == 7 {
+		c.auditLogger.Err(cefString)
+		return
+	}
+
+	c.auditLogger.Debug(cefString)
+}
@@ -0,0 +1,170 @@
+package mbus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	mbuspb "github.com/cloudfoundry/bosh-agent/mbus/mbuspb"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const grpcHandlerLogTag = "gRPC Handler"
+
+// grpcHandler streams agent tasks over a single bidirectional gRPC stream,
+// giving operators a transport that multiplexes over one long-lived HTTP/2
+// connection rather than NATS's persistent TCP connection.
+type grpcHandler struct {
+	settingsService boshsettings.Service
+
+	conn   *grpc.ClientConn
+	stream mbuspb.AgentMbus_StreamClient
+
+	handlerFuncs     []boshhandler.Func
+	handlerFuncsLock sync.Mutex
+
+	cancel context.CancelFunc
+	stopCh chan struct{}
+
+	logger boshlog.Logger
+	audit  cefAuditLogger
+	logTag string
+}
+
+func NewGRPCHandler(
+	settingsService boshsettings.Service,
+	logger boshlog.Logger,
+	platform boshplatform.Platform,
+) Handler {
+	return &grpcHandler{
+		settingsService: settingsService,
+		stopCh:          make(chan struct{}),
+
+		logger: logger,
+		logTag: grpcHandlerLogTag,
+		audit:  cefAuditLogger{auditLogger: platform.GetAuditLogger(), logger: logger, logTag: grpcHandlerLogTag},
+	}
+}
+
+func (h *grpcHandler) Run(handlerFunc boshhandler.Func) error {
+	err := h.Start(handlerFunc)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting grpc handler")
+	}
+	<-h.stopCh
+	return nil
+}
+
+func (h *grpcHandler) Start(handlerFunc boshhandler.Func) error {
+	h.RegisterAdditionalFunc(handlerFunc)
+
+	settings := h.settingsService.GetSettings()
+
+	var dialOpts []grpc.DialOption
+	if settings.Env.IsNATSMutualTLSEnabled() {
+		tlsConfig, err := buildMbusTLSConfig(settings)
+		if err != nil {
+			return bosherr.WrapError(err, "Building Mbus TLS config")
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure()) //nolint:staticcheck
+	}
+
+	mbusURL := settings.GetMbusURL()
+	conn, err := grpc.Dial(mbusURL, dialOpts...)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Dialing %s", mbusURL)
+	}
+	h.conn = conn
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	client := mbuspb.NewAgentMbusClient(conn)
+	stream, err := client.Stream(ctx)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening agent mbus stream")
+	}
+	h.stream = stream
+
+	go h.recvLoop()
+
+	return nil
+}
+
+func (h *grpcHandler) recvLoop() {
+	for {
+		msg, err := h.stream.Recv()
+		if err != nil {
+			h.logger.Error(h.logTag, "Receiving from mbus stream: %s", err.Error())
+			return
+		}
+
+		h.handlerFuncsLock.Lock()
+		handlerFuncs := h.handlerFuncs
+		h.handlerFuncsLock.Unlock()
+
+		for _, handlerFunc := range handlerFuncs {
+			h.handleTask(msg.Payload, handlerFunc)
+		}
+	}
+}
+
+func (h *grpcHandler) handleTask(payload []byte, handlerFunc boshhandler.Func) {
+	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
+		payload,
+		handlerFunc,
+		responseMaxLength,
+		h.logger,
+	)
+	if err != nil {
+		h.logger.Error(h.logTag, "Running handler: %s", err)
+		h.audit.Log("", "", req.ReplyTo, req.Method, 7, "", err.Error())
+		return
+	}
+
+	if len(respBytes) == 0 {
+		return
+	}
+
+	if err := h.stream.Send(&mbuspb.AgentMessage{Payload: respBytes}); err != nil {
+		h.audit.Log("", "", req.ReplyTo, req.Method, 7, "", err.Error())
+		h.logger.Error(h.logTag, "Sending task response: %s", err.Error())
+		return
+	}
+
+	h.audit.Log("", "", req.ReplyTo, req.Method, 1, "", "")
+}
+
+func (h *grpcHandler) RegisterAdditionalFunc(handlerFunc boshhandler.Func) {
+	h.handlerFuncsLock.Lock()
+	h.handlerFuncs = append(h.handlerFuncs, handlerFunc)
+	h.handlerFuncsLock.Unlock()
+}
+
+func (h *grpcHandler) Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error {
+	bytesMsg, err := json.Marshal(message)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling message (target=%s, topic=%s): %#v", target, topic, message)
+	}
+
+	return h.stream.Send(&mbuspb.AgentMessage{Payload: bytesMsg})
+}
+
+func (h *grpcHandler) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	if h.conn != nil {
+		h.conn.Close() //nolint:errcheck
+	}
+	close(h.stopCh)
+}
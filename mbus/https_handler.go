@@ -0,0 +1,218 @@
+package mbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	httpsHandlerLogTag = "HTTPS Handler"
+	longPollTimeout    = 55 * time.Second
+)
+
+// httpsHandler is a long-poll transport for operators whose network blocks
+// the long-lived connections NATS needs: the agent repeatedly GETs its own
+// task queue and POSTs results back, instead of holding a subscription open.
+type httpsHandler struct {
+	settingsService boshsettings.Service
+	httpClient      *http.Client
+
+	handlerFuncs     []boshhandler.Func
+	handlerFuncsLock sync.Mutex
+
+	stopCh chan struct{}
+
+	logger boshlog.Logger
+	audit  cefAuditLogger
+	logTag string
+}
+
+func NewHTTPSHandler(
+	settingsService boshsettings.Service,
+	logger boshlog.Logger,
+	platform boshplatform.Platform,
+) Handler {
+	return &httpsHandler{
+		settingsService: settingsService,
+		stopCh:          make(chan struct{}),
+
+		logger: logger,
+		logTag: httpsHandlerLogTag,
+		audit:  cefAuditLogger{auditLogger: platform.GetAuditLogger(), logger: logger, logTag: httpsHandlerLogTag},
+	}
+}
+
+func (h *httpsHandler) Run(handlerFunc boshhandler.Func) error {
+	err := h.Start(handlerFunc)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting https handler")
+	}
+	<-h.stopCh
+	return nil
+}
+
+func (h *httpsHandler) Start(handlerFunc boshhandler.Func) error {
+	h.RegisterAdditionalFunc(handlerFunc)
+
+	tlsConfig, err := buildMbusTLSConfig(h.settingsService.GetSettings())
+	if err != nil {
+		return bosherr.WrapError(err, "Building Mbus TLS config")
+	}
+
+	h.httpClient = &http.Client{
+		Timeout:   longPollTimeout + 10*time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	go h.pollLoop()
+
+	return nil
+}
+
+func (h *httpsHandler) pollLoop() {
+	for {
+		select {
+		case <-h.stopCh:
+			return
+		default:
+		}
+
+		payload, err := h.longPollForTask()
+		if err != nil {
+			h.logger.Error(h.logTag, "Long-polling for task: %s", err.Error())
+			continue
+		}
+		if len(payload) == 0 {
+			continue
+		}
+
+		h.handlerFuncsLock.Lock()
+		handlerFuncs := h.handlerFuncs
+		h.handlerFuncsLock.Unlock()
+
+		for _, handlerFunc := range handlerFuncs {
+			h.handleTask(payload, handlerFunc)
+		}
+	}
+}
+
+func (h *httpsHandler) longPollForTask() ([]byte, error) {
+	tasksURL := h.tasksURL()
+
+	resp, err := h.httpClient.Get(tasksURL)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "GETing %s", tasksURL)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading task response body")
+	}
+
+	return body, nil
+}
+
+func (h *httpsHandler) handleTask(payload []byte, handlerFunc boshhandler.Func) {
+	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
+		payload,
+		handlerFunc,
+		responseMaxLength,
+		h.logger,
+	)
+	if err != nil {
+		h.logger.Error(h.logTag, "Running handler: %s", err)
+		h.audit.Log(h.host(), h.port(), req.ReplyTo, req.Method, 7, "", err.Error())
+		return
+	}
+
+	if len(respBytes) == 0 {
+		return
+	}
+
+	resp, err := h.httpClient.Post(h.tasksURL(), "application/json", bytes.NewReader(respBytes))
+	if err != nil {
+		h.audit.Log(h.host(), h.port(), req.ReplyTo, req.Method, 7, "", err.Error())
+		h.logger.Error(h.logTag, "Posting task response: %s", err.Error())
+		return
+	}
+	resp.Body.Close() //nolint:errcheck
+
+	h.audit.Log(h.host(), h.port(), req.ReplyTo, req.Method, 1, "", "")
+}
+
+func (h *httpsHandler) RegisterAdditionalFunc(handlerFunc boshhandler.Func) {
+	h.handlerFuncsLock.Lock()
+	h.handlerFuncs = append(h.handlerFuncs, handlerFunc)
+	h.handlerFuncsLock.Unlock()
+}
+
+func (h *httpsHandler) Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error {
+	bytesMsg, err := json.Marshal(message)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling message (target=%s, topic=%s): %#v", target, topic, message)
+	}
+
+	settings := h.settingsService.GetSettings()
+	sendURL := fmt.Sprintf("%s/agent/%s/%s/%s", h.baseURL(), settings.AgentID, target, topic)
+
+	resp, err := h.httpClient.Post(sendURL, "application/json", bytes.NewReader(bytesMsg))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "POSTing %s", sendURL)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	return nil
+}
+
+func (h *httpsHandler) Stop() {
+	close(h.stopCh)
+}
+
+func (h *httpsHandler) baseURL() string {
+	settings := h.settingsService.GetSettings()
+	mbusURL, err := url.Parse(settings.GetMbusURL())
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("https://%s", mbusURL.Host)
+}
+
+func (h *httpsHandler) tasksURL() string {
+	settings := h.settingsService.GetSettings()
+	return fmt.Sprintf("%s/agent/%s/tasks", h.baseURL(), settings.AgentID)
+}
+
+func (h *httpsHandler) host() string {
+	settings := h.settingsService.GetSettings()
+	mbusURL, err := url.Parse(settings.GetMbusURL())
+	if err != nil {
+		return ""
+	}
+	return mbusURL.Hostname()
+}
+
+func (h *httpsHandler) port() string {
+	settings := h.settingsService.GetSettings()
+	mbusURL, err := url.Parse(settings.GetMbusURL())
+	if err != nil {
+		return ""
+	}
+	return mbusURL.Port()
+}
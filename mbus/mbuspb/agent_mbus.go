@@ -0,0 +1,148 @@
+// Package mbuspb defines the wire message and service for the agent's gRPC
+// mbus transport: a single bidirectional stream of opaque JSON envelopes,
+// the same payload shape the NATS and HTTPS transports already carry.
+// Since the envelope is one opaque byte slice, this is hand-written rather
+// than generated from a .proto — there's no structured message to gain
+// from protobuf reflection, just a byte-passthrough codec registered with
+// gRPC.
+package mbuspb
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+const codecName = "bosh-agent-raw"
+
+func init() {
+	encoding.RegisterCodec(rawCodec{})
+}
+
+// AgentMessage is the single message exchanged over the agent mbus gRPC
+// stream.
+type AgentMessage struct {
+	Payload []byte
+}
+
+// rawCodec passes AgentMessage.Payload straight through as the wire
+// bytes, skipping protobuf encoding entirely.
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return codecName }
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(*AgentMessage)
+	if !ok {
+		return nil, fmt.Errorf("mbuspb: cannot marshal %T", v)
+	}
+	return msg.Payload, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(*AgentMessage)
+	if !ok {
+		return fmt.Errorf("mbuspb: cannot unmarshal into %T", v)
+	}
+	msg.Payload = data
+	return nil
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "mbuspb.AgentMbus",
+	HandlerType: (*AgentMbusServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       streamHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+// AgentMbusClient is the client side of the single AgentMbus.Stream RPC.
+type AgentMbusClient interface {
+	Stream(ctx context.Context, opts ...grpc.CallOption) (AgentMbus_StreamClient, error)
+}
+
+type agentMbusClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAgentMbusClient(cc *grpc.ClientConn) AgentMbusClient {
+	return &agentMbusClient{cc: cc}
+}
+
+func (c *agentMbusClient) Stream(ctx context.Context, opts ...grpc.CallOption) (AgentMbus_StreamClient, error) {
+	opts = append(opts, grpc.CallContentSubtype(codecName))
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], "/mbuspb.AgentMbus/Stream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &agentMbusStream{stream}, nil
+}
+
+// AgentMbus_StreamClient is the client's view of the stream: it sends
+// task responses and receives task requests.
+type AgentMbus_StreamClient interface {
+	Send(*AgentMessage) error
+	Recv() (*AgentMessage, error)
+	grpc.ClientStream
+}
+
+// AgentMbus_StreamServer is the server's view of the stream: it sends
+// task requests and receives task responses.
+type AgentMbus_StreamServer interface {
+	Send(*AgentMessage) error
+	Recv() (*AgentMessage, error)
+	grpc.ServerStream
+}
+
+type agentMbusStream struct {
+	grpc.ClientStream
+}
+
+func (s *agentMbusStream) Send(m *AgentMessage) error {
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *agentMbusStream) Recv() (*AgentMessage, error) {
+	m := new(AgentMessage)
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type agentMbusServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *agentMbusServerStream) Send(m *AgentMessage) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *agentMbusServerStream) Recv() (*AgentMessage, error) {
+	m := new(AgentMessage)
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AgentMbusServer is implemented by whatever terminates the agent's gRPC
+// mbus stream (e.g. a director-side gateway).
+type AgentMbusServer interface {
+	Stream(AgentMbus_StreamServer) error
+}
+
+func RegisterAgentMbusServer(s *grpc.Server, srv AgentMbusServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+func streamHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentMbusServer).Stream(&agentMbusServerStream{stream})
+}
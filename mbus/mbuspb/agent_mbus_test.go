@@ -0,0 +1,33 @@
+package mbuspb
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMbuspb(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "mbus/mbuspb")
+}
+
+var _ = Describe("rawCodec", func() {
+	It("round-trips an AgentMessage payload without protobuf framing", func() {
+		codec := rawCodec{}
+
+		bytes, err := codec.Marshal(&AgentMessage{Payload: []byte(`{"hello":"world"}`)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bytes).To(Equal([]byte(`{"hello":"world"}`)))
+
+		msg := &AgentMessage{}
+		Expect(codec.Unmarshal(bytes, msg)).To(Succeed())
+		Expect(msg.Payload).To(Equal([]byte(`{"hello":"world"}`)))
+	})
+
+	It("rejects non-AgentMessage values", func() {
+		codec := rawCodec{}
+		_, err := codec.Marshal("not an AgentMessage")
+		Expect(err).To(HaveOccurred())
+	})
+})
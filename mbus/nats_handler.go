@@ -12,7 +12,7 @@ import (
 	"sync"
 	"syscall"
 
-	"github.com/cloudfoundry/yagnats"
+	"github.com/nats-io/nats.go"
 
 	"crypto/x509"
 	"time"
@@ -31,6 +31,7 @@ const (
 	responseMaxLength        = 1024 * 1024
 	natsHandlerLogTag        = "NATS Handler"
 	natsConnectionMaxRetries = 4
+	jetStreamAckTimeout      = 5 * time.Second
 )
 
 type Handler interface {
@@ -43,31 +44,30 @@ type Handler interface {
 
 type natsHandler struct {
 	settingsService boshsettings.Service
-	client          yagnats.NATSClient
+	client          *nats.Conn
+	js              nats.JetStreamContext
 	platform        boshplatform.Platform
 
 	handlerFuncs     []boshhandler.Func
 	handlerFuncsLock sync.Mutex
 
-	logger      boshlog.Logger
-	auditLogger boshplatform.AuditLogger
-	logTag      string
+	logger boshlog.Logger
+	audit  cefAuditLogger
+	logTag string
 }
 
 func NewNatsHandler(
 	settingsService boshsettings.Service,
-	client yagnats.NATSClient,
 	logger boshlog.Logger,
 	platform boshplatform.Platform,
 ) Handler {
 	return &natsHandler{
 		settingsService: settingsService,
-		client:          client,
 		platform:        platform,
 
-		logger:      logger,
-		logTag:      natsHandlerLogTag,
-		auditLogger: platform.GetAuditLogger(),
+		logger: logger,
+		logTag: natsHandlerLogTag,
+		audit:  cefAuditLogger{auditLogger: platform.GetAuditLogger(), logger: logger, logTag: natsHandlerLogTag},
 	}
 }
 
@@ -87,30 +87,19 @@ func (h *natsHandler) Run(handlerFunc boshhandler.Func) error {
 func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 	h.RegisterAdditionalFunc(handlerFunc)
 
-	connProvider, err := h.getConnectionInfo()
+	opts, host, err := h.getConnectionOptions()
 	if err != nil {
 		return bosherr.WrapError(err, "Getting connection info")
 	}
 
-	h.client.BeforeConnectCallback(func() {
-		hostSplit := strings.Split(connProvider.Addr, ":")
-		ip := hostSplit[0]
-
-		if net.ParseIP(ip) == nil {
-			return
-		}
-
-		err = h.platform.DeleteARPEntryWithIP(ip)
-		if err != nil {
-			h.logger.Error(h.logTag, "Cleaning ip-mac address cache for: %s", ip)
-		}
-	})
-
 	natsRetryable := boshretry.NewRetryable(func() (bool, error) {
-		err := h.client.Connect(connProvider)
+		h.cleanUpARPEntry(host)
+
+		client, err := nats.Connect(h.settingsService.GetSettings().GetMbusURL(), opts...)
 		if err != nil {
 			return true, bosherr.WrapError(err, "Connecting to NATS")
 		}
+		h.client = client
 		return false, nil
 	})
 
@@ -121,12 +110,15 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 	}
 
 	settings := h.settingsService.GetSettings()
-
 	subject := fmt.Sprintf("agent.%s", settings.AgentID)
 
+	if settings.Env.Bosh.Mbus.JetStream {
+		return h.startJetStream(subject)
+	}
+
 	h.logger.Info(h.logTag, "Subscribing to %s", subject)
 
-	_, err = h.client.Subscribe(subject, func(natsMsg *yagnats.Message) {
+	_, err = h.client.Subscribe(subject, func(natsMsg *nats.Msg) {
 		// Do not lock handler funcs around possible network calls!
 		h.handlerFuncsLock.Lock()
 		handlerFuncs := h.handlerFuncs
@@ -143,6 +135,49 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 	return nil
 }
 
+// startJetStream binds (creating if necessary) a durable JetStream
+// consumer for this agent's subject and acks each message only after
+// every handlerFunc has succeeded on it, so a restart (or a handler
+// error) redelivers rather than silently dropping the director's request.
+func (h *natsHandler) startJetStream(subject string) error {
+	js, err := h.client.JetStream()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting JetStream context")
+	}
+	h.js = js
+
+	durableName := strings.ReplaceAll(subject, ".", "_")
+
+	h.logger.Info(h.logTag, "Subscribing to %s via JetStream durable consumer %s", subject, durableName)
+
+	_, err = js.Subscribe(subject, func(natsMsg *nats.Msg) {
+		h.handlerFuncsLock.Lock()
+		handlerFuncs := h.handlerFuncs
+		h.handlerFuncsLock.Unlock()
+
+		succeeded := true
+		for _, handlerFunc := range handlerFuncs {
+			if !h.handleNatsMsg(natsMsg, handlerFunc) {
+				succeeded = false
+			}
+		}
+
+		if !succeeded {
+			h.logger.Error(h.logTag, "Not acking JetStream message %s: handler failed", natsMsg.Subject)
+			return
+		}
+
+		if err := natsMsg.Ack(); err != nil {
+			h.logger.Error(h.logTag, "Acking JetStream message: %s", err.Error())
+		}
+	}, nats.Durable(durableName), nats.ManualAck())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Subscribing to %s via JetStream", subject)
+	}
+
+	return nil
+}
+
 func (h *natsHandler) RegisterAdditionalFunc(handlerFunc boshhandler.Func) {
 	// Currently not locking since RegisterAdditionalFunc
 	// is not a primary way of adding handlerFunc.
@@ -164,6 +199,10 @@ func (h *natsHandler) Send(target boshhandler.Target, topic boshhandler.Topic, m
 
 	subject := fmt.Sprintf("%s.agent.%s.%s", target, topic, settings.AgentID)
 
+	if h.js != nil {
+		return h.sendViaJetStream(subject, bytes)
+	}
+
 	publishRetryable := boshretry.NewRetryable(func() (bool, error) {
 		err := h.client.Publish(subject, bytes)
 		if err != nil {
@@ -175,8 +214,31 @@ func (h *natsHandler) Send(target boshhandler.Target, topic boshhandler.Topic, m
 	return attemptRetryStrategy.Try()
 }
 
+// sendViaJetStream publishes asynchronously and feeds the ack (or publish
+// error) back into the same retry strategy used by the plain NATS path, so
+// a dropped ack is retried rather than silently swallowed.
+func (h *natsHandler) sendViaJetStream(subject string, bytes []byte) error {
+	publishRetryable := boshretry.NewRetryable(func() (bool, error) {
+		future, err := h.js.PublishAsync(subject, bytes)
+		if err != nil {
+			return true, bosherr.WrapError(err, "Publishing to JetStream")
+		}
+
+		select {
+		case <-future.Ok():
+			return false, nil
+		case err := <-future.Err():
+			return true, bosherr.WrapError(err, "Acking JetStream publish")
+		case <-time.After(jetStreamAckTimeout):
+			return true, bosherr.Error("Timed out waiting for JetStream ack")
+		}
+	})
+	attemptRetryStrategy := boshretry.NewAttemptRetryStrategy(3, time.Second, publishRetryable, h.logger)
+	return attemptRetryStrategy.Try()
+}
+
 func (h *natsHandler) Stop() {
-	h.client.Disconnect()
+	h.client.Close()
 }
 
 func (h *natsHandler) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
@@ -193,9 +255,13 @@ func (h *natsHandler) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains []
 	return errors.New("Server Certificate CommonName does not match *.nats.bosh-internal")
 }
 
-func (h *natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshhandler.Func) {
+// handleNatsMsg runs handlerFunc against natsMsg and publishes its
+// response, if any. It returns whether the whole round trip succeeded, so
+// JetStream's manual-ack subscriber can decide whether the message is safe
+// to ack.
+func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.Func) bool {
 	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
-		natsMsg.Payload,
+		natsMsg.Data,
 		handlerFunc,
 		responseMaxLength,
 		h.logger,
@@ -204,7 +270,7 @@ func (h *natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshha
 	if err != nil {
 		h.logger.Error(h.logTag, "Running handler: %s", err)
 		h.generateCEFLog(natsMsg, 7, err.Error())
-		return
+		return false
 	}
 
 	if len(respBytes) > 0 {
@@ -212,15 +278,16 @@ func (h *natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshha
 		if err != nil {
 			h.generateCEFLog(natsMsg, 7, err.Error())
 			h.logger.Error(h.logTag, "Publishing to the client: %s", err.Error())
-			return
+			return false
 		}
 	}
 
 	h.generateCEFLog(natsMsg, 1, "")
+	return true
 }
 
 func (h *natsHandler) runUntilInterrupted() {
-	defer h.client.Disconnect()
+	defer h.client.Close()
 
 	keepRunning := true
 
@@ -235,53 +302,69 @@ func (h *natsHandler) runUntilInterrupted() {
 	}
 }
 
-func (h *natsHandler) getConnectionInfo() (*yagnats.ConnectionInfo, error) {
+func (h *natsHandler) cleanUpARPEntry(host string) {
+	hostSplit := strings.Split(host, ":")
+	ip := hostSplit[0]
+
+	if net.ParseIP(ip) == nil {
+		return
+	}
+
+	if err := h.platform.DeleteARPEntryWithIP(ip); err != nil {
+		h.logger.Error(h.logTag, "Cleaning ip-mac address cache for: %s", ip)
+	}
+}
+
+func (h *natsHandler) getConnectionOptions() ([]nats.Option, string, error) {
 	settings := h.settingsService.GetSettings()
 
 	natsURL, err := url.Parse(settings.GetMbusURL())
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Parsing Nats URL")
+		return nil, "", bosherr.WrapError(err, "Parsing Nats URL")
 	}
 
-	connInfo := new(yagnats.ConnectionInfo)
-	connInfo.Addr = natsURL.Host
+	opts := []nats.Option{
+		nats.MaxReconnects(-1),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			h.cleanUpARPEntry(natsURL.Host)
+		}),
+	}
 
 	if settings.Env.IsNATSMutualTLSEnabled() {
-		connInfo.TLSInfo = &yagnats.ConnectionTLSInfo{}
+		tlsConfig := &tls.Config{} //nolint:gosec
 
 		caCert := settings.Env.Bosh.Mbus.Cert.CA
 		if caCert != "" {
-			connInfo.TLSInfo.CertPool = x509.NewCertPool()
-			if ok := connInfo.TLSInfo.CertPool.AppendCertsFromPEM([]byte(caCert)); !ok {
-				return nil, bosherr.Error("Failed to load Mbus CA cert")
+			certPool := x509.NewCertPool()
+			if ok := certPool.AppendCertsFromPEM([]byte(caCert)); !ok {
+				return nil, "", bosherr.Error("Failed to load Mbus CA cert")
 			}
+			tlsConfig.RootCAs = certPool
 		}
 
-		connInfo.TLSInfo.VerifyPeerCertificate = h.VerifyPeerCertificate
+		tlsConfig.VerifyPeerCertificate = h.VerifyPeerCertificate
 
 		clientCertificate, err := tls.X509KeyPair([]byte(settings.Env.Bosh.Mbus.Cert.Certificate), []byte(settings.Env.Bosh.Mbus.Cert.PrivateKey))
 		if err != nil {
-			return nil, bosherr.WrapError(err, "Parsing certificate and private key")
+			return nil, "", bosherr.WrapError(err, "Parsing certificate and private key")
 		}
-		connInfo.TLSInfo.ClientCert = &clientCertificate
+		tlsConfig.Certificates = []tls.Certificate{clientCertificate}
+
+		opts = append(opts, nats.Secure(tlsConfig))
 	}
 
-	user := natsURL.User
-	if user != nil {
+	if user := natsURL.User; user != nil {
 		password, passwordIsSet := user.Password()
 		if !passwordIsSet {
-			return nil, errors.New("No password set for connection")
+			return nil, "", errors.New("No password set for connection")
 		}
-		connInfo.Password = password
-		connInfo.Username = user.Username()
+		opts = append(opts, nats.UserInfo(user.Username(), password))
 	}
 
-	return connInfo, nil
+	return opts, natsURL.Host, nil
 }
 
-func (h *natsHandler) generateCEFLog(natsMsg *yagnats.Message, severity int, statusReason string) {
-	cef := boshhandler.NewCommonEventFormat()
-
+func (h *natsHandler) generateCEFLog(natsMsg *nats.Msg, severity int, statusReason string) {
 	settings := h.settingsService.GetSettings()
 
 	natsURL, err := url.Parse(settings.GetMbusURL())
@@ -296,21 +379,10 @@ func (h *natsHandler) generateCEFLog(natsMsg *yagnats.Message, severity int, sta
 		Method  string `json:"method"`
 		ReplyTo string `json:"reply_to"`
 	}{}
-	err = json.Unmarshal(natsMsg.Payload, &payload)
+	err = json.Unmarshal(natsMsg.Data, &payload)
 	if err != nil {
 		h.logger.Error(natsHandlerLogTag, err.Error())
 	}
-	cefString, err := cef.ProduceNATSRequestEventLog(ip, hostSplit[1], payload.ReplyTo, payload.Method, severity, natsMsg.Subject, statusReason)
-
-	if err != nil {
-		h.logger.Error(natsHandlerLogTag, err.Error())
-		return
-	}
-
-	if severity == 7 {
-		h.auditLogger.Err(cefString)
-		return
-	}
 
-	h.auditLogger.Debug(cefString)
+	h.audit.Log(ip, hostSplit[1], payload.ReplyTo, payload.Method, severity, natsMsg.Subject, statusReason)
 }
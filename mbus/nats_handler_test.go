@@ -0,0 +1,14 @@
+package mbus
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("jetStreamAckTimeout", func() {
+	It("is a real wall-clock timeout, not responseMaxLength reinterpreted as a duration", func() {
+		Expect(jetStreamAckTimeout).To(BeNumerically(">=", time.Second))
+	})
+})
@@ -0,0 +1,94 @@
+package mbus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"regexp"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// TransportFactory picks the Handler implementation to use based on the
+// scheme of the configured mbus URL, so operators can swap NATS for a
+// transport that tolerates restrictive egress (e.g. HTTPS long-poll or
+// gRPC) without the rest of the agent knowing the difference.
+type TransportFactory interface {
+	NewHandler(settingsService boshsettings.Service, logger boshlog.Logger, platform boshplatform.Platform) (Handler, error)
+}
+
+type transportFactory struct{}
+
+func NewTransportFactory() TransportFactory {
+	return transportFactory{}
+}
+
+func (f transportFactory) NewHandler(settingsService boshsettings.Service, logger boshlog.Logger, platform boshplatform.Platform) (Handler, error) {
+	mbusURL, err := url.Parse(settingsService.GetSettings().GetMbusURL())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing Mbus URL")
+	}
+
+	switch mbusURL.Scheme {
+	case "nats", "nats+tls":
+		return NewNatsHandler(settingsService, logger, platform), nil
+	case "https":
+		return NewHTTPSHandler(settingsService, logger, platform), nil
+	case "grpc":
+		return NewGRPCHandler(settingsService, logger, platform), nil
+	default:
+		return nil, bosherr.Errorf("Unknown mbus transport scheme '%s'", mbusURL.Scheme)
+	}
+}
+
+// buildMbusTLSConfig builds the mTLS config used to talk to the mbus
+// endpoint, mirroring the NATS handler's Secure() setup: the director's CA
+// verifies the server, the agent's own cert/key authenticate it, and
+// verifyMbusPeerCertificate pins the expected CommonName. Returns a nil
+// config (plain TLS/plaintext, transport-dependent) when mutual TLS isn't
+// enabled for this mbus.
+func buildMbusTLSConfig(settings boshsettings.Settings) (*tls.Config, error) {
+	if !settings.Env.IsNATSMutualTLSEnabled() {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{} //nolint:gosec
+
+	caCert := settings.Env.Bosh.Mbus.Cert.CA
+	if caCert != "" {
+		certPool := x509.NewCertPool()
+		if ok := certPool.AppendCertsFromPEM([]byte(caCert)); !ok {
+			return nil, bosherr.Error("Failed to load Mbus CA cert")
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	tlsConfig.VerifyPeerCertificate = verifyMbusPeerCertificate
+
+	clientCertificate, err := tls.X509KeyPair([]byte(settings.Env.Bosh.Mbus.Cert.Certificate), []byte(settings.Env.Bosh.Mbus.Cert.PrivateKey))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing certificate and private key")
+	}
+	tlsConfig.Certificates = []tls.Certificate{clientCertificate}
+
+	return tlsConfig, nil
+}
+
+// verifyMbusPeerCertificate is the HTTPS/gRPC transports' counterpart to
+// natsHandler.VerifyPeerCertificate: it pins the mbus server cert to the
+// same CommonName convention regardless of which transport carries it.
+func verifyMbusPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	for _, chain := range verifiedChains {
+		if len(chain) == 0 {
+			continue
+		}
+		commonName := chain[0].Subject.CommonName
+		if match, _ := regexp.MatchString(`^[a-zA-Z0-9*\-]*.nats.bosh-internal$`, commonName); match {
+			return nil
+		}
+	}
+	return bosherr.Error("Server Certificate CommonName does not match *.nats.bosh-internal")
+}
@@ -0,0 +1,22 @@
+package mbus
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+var _ = Describe("buildMbusTLSConfig", func() {
+	It("returns a nil config when mutual TLS is not enabled", func() {
+		tlsConfig, err := buildMbusTLSConfig(boshsettings.Settings{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsConfig).To(BeNil())
+	})
+})
+
+var _ = Describe("verifyMbusPeerCertificate", func() {
+	It("rejects when there are no verified chains", func() {
+		Expect(verifyMbusPeerCertificate(nil, nil)).To(HaveOccurred())
+	})
+})
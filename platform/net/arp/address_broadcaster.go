@@ -0,0 +1,70 @@
+package arp
+
+import (
+	"net"
+	"time"
+
+	"github.com/j-keck/arping"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const arpAddressBroadcasterLogTag = "arpAddressBroadcaster"
+
+// AddressBroadcaster sends gratuitous ARP for a batch of addresses, so
+// switches relearn the MAC for an address this agent's VM just inherited
+// from a dead peer instead of keeping a stale ARP cache entry.
+type AddressBroadcaster interface {
+	BroadcastMACAddresses(addresses []InterfaceAddress)
+}
+
+type arpAddressBroadcaster struct {
+	count    int
+	interval time.Duration
+
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewAddressBroadcaster returns an AddressBroadcaster that gratuitously
+// ARPs each address count times, interval apart, over the arping library.
+func NewAddressBroadcaster(count int, interval time.Duration, logger boshlog.Logger) AddressBroadcaster {
+	return arpAddressBroadcaster{
+		count:    count,
+		interval: interval,
+
+		logger: logger,
+		logTag: arpAddressBroadcasterLogTag,
+	}
+}
+
+func (b arpAddressBroadcaster) BroadcastMACAddresses(addresses []InterfaceAddress) {
+	for _, address := range addresses {
+		address := address
+		go b.broadcastMACAddress(address)
+	}
+}
+
+func (b arpAddressBroadcaster) broadcastMACAddress(address InterfaceAddress) {
+	ip, err := address.GetIP()
+	if err != nil {
+		b.logger.Error(b.logTag, "Getting IP for interface '%s': %s", address.GetInterfaceName(), err.Error())
+		return
+	}
+
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		b.logger.Error(b.logTag, "Parsing IP '%s' for interface '%s'", ip, address.GetInterfaceName())
+		return
+	}
+
+	for i := 0; i < b.count; i++ {
+		if i > 0 {
+			time.Sleep(b.interval)
+		}
+
+		if err := arping.GratuitousArpOverIfaceByName(parsedIP, address.GetInterfaceName()); err != nil {
+			b.logger.Error(b.logTag, "Broadcasting MAC address for IP '%s' on interface '%s': %s", ip, address.GetInterfaceName(), err.Error())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package arp
+
+// InterfaceAddress pairs an interface name with the IP that should be
+// gratuitously ARPed on it.
+type InterfaceAddress interface {
+	GetInterfaceName() string
+	GetIP() (string, error)
+}
+
+type simpleInterfaceAddress struct {
+	interfaceName string
+	ip            string
+}
+
+// NewSimpleInterfaceAddress builds an InterfaceAddress from an
+// already-known IP, for callers that don't need to resolve it lazily.
+func NewSimpleInterfaceAddress(interfaceName string, ip string) InterfaceAddress {
+	return simpleInterfaceAddress{interfaceName: interfaceName, ip: ip}
+}
+
+func (a simpleInterfaceAddress) GetInterfaceName() string {
+	return a.interfaceName
+}
+
+func (a simpleInterfaceAddress) GetIP() (string, error) {
+	return a.ip, nil
+}
@@ -0,0 +1,96 @@
+package net
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateCentOSInterfaceFiles renders one ifcfg-<name> file per
+// configuration, keyed by filename (e.g. "ifcfg-eth0"), the format
+// CentOS/RHEL's network-scripts expect under
+// /etc/sysconfig/network-scripts.
+func GenerateCentOSInterfaceFiles(staticConfigs StaticInterfaceConfigurations, dhcpConfigs DHCPInterfaceConfigurations) map[string]string {
+	files := map[string]string{}
+
+	for _, conf := range dhcpConfigs {
+		files[centosIfcfgFilename(conf.Name)] = renderCentOSDHCPFile(conf)
+	}
+
+	for _, conf := range staticConfigs {
+		files[centosIfcfgFilename(conf.Name)] = renderCentOSStaticFile(conf)
+	}
+
+	return files
+}
+
+func centosIfcfgFilename(name string) string {
+	return fmt.Sprintf("ifcfg-%s", name)
+}
+
+func renderCentOSDHCPFile(conf DHCPInterfaceConfiguration) string {
+	lines := []string{
+		fmt.Sprintf("DEVICE=%s", conf.Name),
+		"BOOTPROTO=dhcp",
+		"ONBOOT=yes",
+	}
+	if conf.DHCPv6 {
+		lines = append(lines, "IPV6INIT=yes", "DHCPV6C=yes")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func renderCentOSStaticFile(conf StaticInterfaceConfiguration) string {
+	lines := []string{
+		fmt.Sprintf("DEVICE=%s", conf.Name),
+		"ONBOOT=yes",
+	}
+
+	if len(conf.Address) > 0 {
+		lines = append(lines,
+			"BOOTPROTO=static",
+			fmt.Sprintf("IPADDR=%s", conf.Address),
+			fmt.Sprintf("NETMASK=%s", conf.Netmask),
+		)
+		if conf.IsDefaultForGateway {
+			lines = append(lines, fmt.Sprintf("GATEWAY=%s", conf.Gateway))
+		}
+	} else {
+		lines = append(lines, "BOOTPROTO=none")
+	}
+
+	if len(conf.Address6) > 0 {
+		lines = append(lines,
+			"IPV6INIT=yes",
+			fmt.Sprintf("IPV6ADDR=%s/%s", conf.Address6, conf.Netmask6OrLen()),
+		)
+		if len(conf.Gateway6) > 0 {
+			lines = append(lines, fmt.Sprintf("IPV6_DEFAULTGW=%s", conf.Gateway6))
+		}
+	}
+
+	if len(conf.MemberOf) > 0 {
+		lines = append(lines, fmt.Sprintf("MASTER=%s", conf.MemberOf), "SLAVE=yes")
+	}
+
+	switch conf.Type {
+	case InterfaceTypeBond:
+		lines = append(lines, "TYPE=Bond", fmt.Sprintf("BONDING_OPTS=%q", bondOptionsString(conf.BondOptions)))
+	case InterfaceTypeVlan:
+		lines = append(lines, "VLAN=yes")
+		if len(conf.Slaves) > 0 {
+			lines = append(lines, fmt.Sprintf("PHYSDEV=%s", conf.Slaves[0]))
+		}
+	case InterfaceTypeBridge:
+		lines = append(lines, "TYPE=Bridge")
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func bondOptionsString(options map[string]string) string {
+	parts := make([]string, 0, len(options))
+	for _, opt := range sortedKeys(options) {
+		parts = append(parts, fmt.Sprintf("%s=%s", opt, options[opt]))
+	}
+	return strings.Join(parts, " ")
+}
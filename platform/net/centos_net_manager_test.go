@@ -0,0 +1,53 @@
+package net_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+)
+
+var _ = Describe("GenerateCentOSInterfaceFiles", func() {
+	It("renders one ifcfg file per interface, with the IPv6 fields set for a dual-stack interface", func() {
+		files := boshnet.GenerateCentOSInterfaceFiles(boshnet.StaticInterfaceConfigurations{
+			{
+				Name:                "eth0",
+				Address:             "1.2.3.4",
+				Netmask:             "255.255.255.0",
+				Gateway:             "1.2.3.1",
+				IsDefaultForGateway: true,
+				Address6:            "2001:db8::1",
+				Netmask6:            "64",
+				Gateway6:            "2001:db8::ffff",
+			},
+		}, nil)
+
+		Expect(files).To(HaveKey("ifcfg-eth0"))
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("DEVICE=eth0"))
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("IPADDR=1.2.3.4"))
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("GATEWAY=1.2.3.1"))
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("IPV6ADDR=2001:db8::1/64"))
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("IPV6_DEFAULTGW=2001:db8::ffff"))
+	})
+
+	It("marks bond members with MASTER/SLAVE and renders BONDING_OPTS on the bond", func() {
+		files := boshnet.GenerateCentOSInterfaceFiles(boshnet.StaticInterfaceConfigurations{
+			{Name: "eth0", MemberOf: "bond0"},
+			{Name: "bond0", Address: "10.0.0.5", Netmask: "255.255.255.0", Type: boshnet.InterfaceTypeBond, Slaves: []string{"eth0"}, BondOptions: map[string]string{"mode": "active-backup"}},
+		}, nil)
+
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("MASTER=bond0"))
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("SLAVE=yes"))
+		Expect(files["ifcfg-bond0"]).To(ContainSubstring("TYPE=Bond"))
+		Expect(files["ifcfg-bond0"]).To(ContainSubstring("BONDING_OPTS=\"mode=active-backup\""))
+	})
+
+	It("renders BOOTPROTO=none for an IPv6-only interface", func() {
+		files := boshnet.GenerateCentOSInterfaceFiles(boshnet.StaticInterfaceConfigurations{
+			{Name: "eth0", Address6: "2001:db8::1", Netmask6: "64"},
+		}, nil)
+
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("BOOTPROTO=none"))
+		Expect(files["ifcfg-eth0"]).To(ContainSubstring("IPV6ADDR=2001:db8::1/64"))
+	})
+})
@@ -10,6 +10,14 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+// Composite interface types a StaticInterfaceConfiguration.Type may take.
+// An empty Type is a plain physical interface.
+const (
+	InterfaceTypeBond   = "bond"
+	InterfaceTypeVlan   = "vlan"
+	InterfaceTypeBridge = "bridge"
+)
+
 type StaticInterfaceConfiguration struct {
 	Name                string
 	Address             string
@@ -20,6 +28,44 @@ type StaticInterfaceConfiguration struct {
 	Mac                 string
 	Gateway             string
 	PostUpRoutes        boshsettings.Routes
+
+	// IPv6 counterparts, populated when the interface is dual-stack (both an
+	// IPv4 and an IPv6 address configured at once). Address6 is empty for a
+	// plain IPv4-only interface.
+	Address6 string
+	Netmask6 string
+	Gateway6 string
+
+	// Type selects a composite interface (InterfaceTypeBond/Vlan/Bridge).
+	// Empty means a plain physical interface.
+	Type string
+	// Slaves names the member interfaces for a bond or bridge. For a vlan,
+	// Slaves[0] is the raw device the vlan sits on top of.
+	Slaves      []string
+	VlanID      int
+	BondOptions map[string]string
+
+	// MemberOf names the composite interface (see Type/Slaves above) this
+	// interface was synthesized as a member of. It's set only on the
+	// member configurations synthesizeMemberConfigurations adds for a
+	// slave that has no network settings of its own, so net managers
+	// still bring the interface up (with no address) instead of ignoring
+	// it entirely.
+	MemberOf string
+}
+
+// dependsOn names the interfaces that must be configured, in this slice,
+// before this one (bond/bridge member interfaces, or a vlan's raw device).
+func (c StaticInterfaceConfiguration) dependsOn() []string {
+	switch c.Type {
+	case InterfaceTypeBond, InterfaceTypeBridge:
+		return c.Slaves
+	case InterfaceTypeVlan:
+		if len(c.Slaves) > 0 {
+			return c.Slaves[:1]
+		}
+	}
+	return nil
 }
 
 func (c StaticInterfaceConfiguration) Version6() string {
@@ -29,25 +75,83 @@ func (c StaticInterfaceConfiguration) Version6() string {
 	return ""
 }
 
+// IsVersion6 reports whether this configuration describes an IPv6-only
+// interface: an IPv6 address (Address6) and no IPv4 one. It's keyed off
+// the addresses themselves rather than the absence of Network/Broadcast,
+// since a composite interface (see Type) can legitimately have neither an
+// IPv4 nor an IPv6 address of its own when it gets its address from a
+// vlan/bridge stacked on top of it.
 func (c StaticInterfaceConfiguration) IsVersion6() bool {
-	return len(c.Network) == 0 && len(c.Broadcast) == 0
+	return len(c.Address) == 0 && len(c.Address6) > 0
+}
+
+// HasVersion6 reports whether this configuration also carries an IPv6
+// address, whether it's IPv6-only or dual-stack alongside IPv4.
+func (c StaticInterfaceConfiguration) HasVersion6() bool {
+	return len(c.Address6) > 0
 }
 
 func (c StaticInterfaceConfiguration) NetmaskOrLen() string {
 	if c.IsVersion6() {
-		ones, _ := net.IPMask(net.ParseIP(c.Netmask)).Size()
-		return strconv.Itoa(ones)
+		return netmaskToPrefixLen(c.Netmask)
 	}
 	return c.Netmask
 }
 
+// Netmask6OrLen returns the dual-stack IPv6 netmask as a prefix length
+// (e.g. "64"), accepting either a dotted/colon netmask or an already
+// numeric prefix length.
+func (c StaticInterfaceConfiguration) Netmask6OrLen() string {
+	return netmaskToPrefixLen(c.Netmask6)
+}
+
+func netmaskToPrefixLen(netmask string) string {
+	if len(netmask) == 0 {
+		return ""
+	}
+
+	// Some callers already hand us a prefix length (e.g. "64") rather than
+	// a full netmask, so accept that form directly.
+	if ones, err := strconv.Atoi(netmask); err == nil {
+		return strconv.Itoa(ones)
+	}
+
+	ip := net.ParseIP(netmask)
+	if ip == nil {
+		return netmask
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ones, _ := net.IPMask(ip4).Size()
+		return strconv.Itoa(ones)
+	}
+
+	ones, _ := net.IPMask(ip.To16()).Size()
+	return strconv.Itoa(ones)
+}
+
 type StaticInterfaceConfigurations []StaticInterfaceConfiguration
 
 func (configs StaticInterfaceConfigurations) Len() int {
 	return len(configs)
 }
 
+// Less orders a composite interface's member interfaces (see dependsOn)
+// before the composite itself, falling back to interface name. This keeps
+// sort.Sort(StaticInterfaceConfigurations) safe to call after
+// CreateInterfaceConfigurations without undoing the bond/vlan/bridge
+// ordering sortForComposition already established.
 func (configs StaticInterfaceConfigurations) Less(i, j int) bool {
+	for _, dep := range configs[j].dependsOn() {
+		if dep == configs[i].Name {
+			return true
+		}
+	}
+	for _, dep := range configs[i].dependsOn() {
+		if dep == configs[j].Name {
+			return false
+		}
+	}
 	return configs[i].Name < configs[j].Name
 }
 
@@ -57,7 +161,7 @@ func (configs StaticInterfaceConfigurations) Swap(i, j int) {
 
 func (configs StaticInterfaceConfigurations) HasVersion6() bool {
 	for _, config := range configs {
-		if config.IsVersion6() {
+		if config.HasVersion6() {
 			return true
 		}
 	}
@@ -68,6 +172,10 @@ type DHCPInterfaceConfiguration struct {
 	Name         string
 	PostUpRoutes boshsettings.Routes
 	Address      string
+
+	// DHCPv6 additionally brings IPv6 up dynamically (DHCPv6/SLAAC) on this
+	// interface, alongside its IPv4 configuration.
+	DHCPv6 bool
 }
 
 func (c DHCPInterfaceConfiguration) Version6() string {
@@ -78,6 +186,9 @@ func (c DHCPInterfaceConfiguration) Version6() string {
 }
 
 func (c DHCPInterfaceConfiguration) IsVersion6() bool {
+	if c.DHCPv6 {
+		return true
+	}
 	ip := net.ParseIP(c.Address)
 	if ip == nil || ip.To4() != nil {
 		return false
@@ -133,25 +244,51 @@ func (creator interfaceConfigurationCreator) createInterfaceConfiguration(static
 			Name:         ifaceName,
 			PostUpRoutes: networkSettings.Routes,
 			Address:      networkSettings.IP,
+			DHCPv6:       networkSettings.IsDHCPv6(),
 		})
 	} else {
 		creator.logger.Debug(creator.logTag, "Using static networking")
-		networkAddress, broadcastAddress, err := boshsys.CalculateNetworkAndBroadcast(networkSettings.IP, networkSettings.Netmask)
-		if err != nil {
-			return nil, nil, bosherr.WrapError(err, "Calculating Network and Broadcast")
-		}
 
 		conf := StaticInterfaceConfiguration{
 			Name:                ifaceName,
-			Address:             networkSettings.IP,
-			Netmask:             networkSettings.Netmask,
-			Network:             networkAddress,
 			IsDefaultForGateway: networkSettings.IsDefaultFor("gateway"),
-			Broadcast:           broadcastAddress,
 			Mac:                 networkSettings.Mac,
-			Gateway:             networkSettings.Gateway,
 			PostUpRoutes:        networkSettings.Routes,
 		}
+
+		// IP is empty for an IPv6-only interface (see IsVersion6/HasVersion6
+		// above); skip IPv4 network/broadcast calculation in that case
+		// rather than handing CalculateNetworkAndBroadcast an empty address.
+		if networkSettings.IP != "" {
+			networkAddress, broadcastAddress, err := boshsys.CalculateNetworkAndBroadcast(networkSettings.IP, networkSettings.Netmask)
+			if err != nil {
+				return nil, nil, bosherr.WrapError(err, "Calculating Network and Broadcast")
+			}
+
+			conf.Address = networkSettings.IP
+			conf.Netmask = networkSettings.Netmask
+			conf.Network = networkAddress
+			conf.Broadcast = broadcastAddress
+			conf.Gateway = networkSettings.Gateway
+		}
+
+		// Dual-stack (or IPv6-only, when IP above was empty): the same
+		// interface also carries an IPv6 address.
+		if networkSettings.IP6 != "" {
+			conf.Address6 = networkSettings.IP6
+			conf.Netmask6 = networkSettings.Netmask6
+			conf.Gateway6 = networkSettings.Gateway6
+		}
+
+		// Bond/vlan/bridge composition: the interface is a composite of
+		// other (already-configured) interfaces rather than a single NIC.
+		if networkSettings.Type != "" {
+			conf.Type = networkSettings.Type
+			conf.Slaves = networkSettings.Slaves
+			conf.VlanID = networkSettings.VlanID
+			conf.BondOptions = networkSettings.BondOptions
+		}
+
 		staticConfigs = append(staticConfigs, conf)
 	}
 	return staticConfigs, dhcpConfigs, nil
@@ -223,7 +360,71 @@ func (creator interfaceConfigurationCreator) createMultipleInterfaceConfiguratio
 		}
 	}
 
-	return staticConfigs, dhcpConfigs, nil
+	return sortForComposition(synthesizeMemberConfigurations(staticConfigs)), dhcpConfigs, nil
+}
+
+// synthesizeMemberConfigurations adds a plain StaticInterfaceConfiguration
+// for every bond/bridge slave (or vlan raw device) that isn't already
+// configured under its own network settings, so sortForComposition has an
+// entry to order ahead of the composite and net managers still bring the
+// member interface up (with no address of its own).
+func synthesizeMemberConfigurations(configs []StaticInterfaceConfiguration) []StaticInterfaceConfiguration {
+	present := map[string]bool{}
+	for _, c := range configs {
+		present[c.Name] = true
+	}
+
+	for _, c := range configs {
+		for _, slave := range c.dependsOn() {
+			if present[slave] {
+				continue
+			}
+			present[slave] = true
+			configs = append(configs, StaticInterfaceConfiguration{Name: slave, MemberOf: c.Name})
+		}
+	}
+
+	return configs
+}
+
+// sortForComposition orders static configs so that a bond/bridge's member
+// interfaces (or a vlan's raw device) always come before the composite
+// interface built on top of them, which net managers require to bring
+// the stanzas up in the right order.
+func sortForComposition(configs []StaticInterfaceConfiguration) []StaticInterfaceConfiguration {
+	indexByName := map[string]int{}
+	for i, c := range configs {
+		indexByName[c.Name] = i
+	}
+
+	visited := make([]bool, len(configs))
+	order := make([]int, 0, len(configs))
+
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+
+		for _, dep := range configs[i].dependsOn() {
+			if depIndex, ok := indexByName[dep]; ok {
+				visit(depIndex)
+			}
+		}
+
+		order = append(order, i)
+	}
+
+	for i := range configs {
+		visit(i)
+	}
+
+	sorted := make([]StaticInterfaceConfiguration, len(order))
+	for pos, i := range order {
+		sorted[pos] = configs[i]
+	}
+	return sorted
 }
 
 func (creator interfaceConfigurationCreator) getFirstNetwork(networks boshsettings.Networks) boshsettings.Network {
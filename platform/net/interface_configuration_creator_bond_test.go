@@ -0,0 +1,63 @@
+package net_test
+
+import (
+	"sort"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("bond/vlan/bridge composition", func() {
+	var creator boshnet.InterfaceConfigurationCreator
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		creator = boshnet.NewInterfaceConfigurationCreator(logger)
+	})
+
+	It("orders a bond's slave interfaces before the bond itself", func() {
+		networks := boshsettings.Networks{
+			"bond0": boshsettings.Network{
+				Alias:       "bond0",
+				IP:          "10.0.0.1",
+				Netmask:     "255.255.255.0",
+				Type:        boshnet.InterfaceTypeBond,
+				Slaves:      []string{"eth0", "eth1"},
+				BondOptions: map[string]string{"mode": "active-backup"},
+			},
+		}
+
+		static, _, err := creator.CreateInterfaceConfigurations(networks, map[string]string{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(static).To(HaveLen(3))
+
+		// eth0/eth1 have no network settings of their own, so they're
+		// synthesized as plain member interfaces ordered ahead of bond0.
+		Expect(static[0].Name).To(Equal("eth0"))
+		Expect(static[0].MemberOf).To(Equal("bond0"))
+		Expect(static[1].Name).To(Equal("eth1"))
+		Expect(static[1].MemberOf).To(Equal("bond0"))
+
+		conf := static[2]
+		Expect(conf.Name).To(Equal("bond0"))
+		Expect(conf.Type).To(Equal(boshnet.InterfaceTypeBond))
+		Expect(conf.Slaves).To(Equal([]string{"eth0", "eth1"}))
+		Expect(conf.BondOptions).To(Equal(map[string]string{"mode": "active-backup"}))
+	})
+
+	It("keeps a slave sorted before its composite even after a name-based sort.Sort", func() {
+		configs := boshnet.StaticInterfaceConfigurations{
+			{Name: "bond0", Type: boshnet.InterfaceTypeBond, Slaves: []string{"eth0"}},
+			{Name: "eth0"},
+		}
+
+		sort.Sort(configs)
+
+		Expect(configs[0].Name).To(Equal("eth0"))
+		Expect(configs[1].Name).To(Equal("bond0"))
+	})
+})
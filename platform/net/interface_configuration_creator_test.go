@@ -0,0 +1,88 @@
+package net_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("interfaceConfigurationCreator", func() {
+	var creator boshnet.InterfaceConfigurationCreator
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		creator = boshnet.NewInterfaceConfigurationCreator(logger)
+	})
+
+	It("carries the IPv6 address alongside the IPv4 one for a dual-stack network", func() {
+		networks := boshsettings.Networks{
+			"default": boshsettings.Network{
+				Mac:      "aa:bb:cc:dd:ee:ff",
+				IP:       "1.2.3.4",
+				Netmask:  "255.255.255.0",
+				Gateway:  "1.2.3.1",
+				IP6:      "2001:db8::1",
+				Netmask6: "64",
+				Gateway6: "2001:db8::ffff",
+			},
+		}
+
+		static, dhcp, err := creator.CreateInterfaceConfigurations(networks, map[string]string{"aa:bb:cc:dd:ee:ff": "eth0"}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dhcp).To(BeEmpty())
+		Expect(static).To(HaveLen(1))
+
+		conf := static[0]
+		Expect(conf.Address).To(Equal("1.2.3.4"))
+		Expect(conf.Address6).To(Equal("2001:db8::1"))
+		Expect(conf.Gateway6).To(Equal("2001:db8::ffff"))
+		Expect(conf.HasVersion6()).To(BeTrue())
+		Expect(conf.IsVersion6()).To(BeFalse())
+	})
+
+	It("configures an IPv6-only network without an IPv4 network/broadcast calculation", func() {
+		networks := boshsettings.Networks{
+			"default": boshsettings.Network{
+				Mac:      "aa:bb:cc:dd:ee:ff",
+				IP6:      "2001:db8::1",
+				Netmask6: "64",
+				Gateway6: "2001:db8::ffff",
+			},
+		}
+
+		static, dhcp, err := creator.CreateInterfaceConfigurations(networks, map[string]string{"aa:bb:cc:dd:ee:ff": "eth0"}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dhcp).To(BeEmpty())
+		Expect(static).To(HaveLen(1))
+
+		conf := static[0]
+		Expect(conf.Address).To(BeEmpty())
+		Expect(conf.Network).To(BeEmpty())
+		Expect(conf.Broadcast).To(BeEmpty())
+		Expect(conf.Address6).To(Equal("2001:db8::1"))
+		Expect(conf.IsVersion6()).To(BeTrue())
+	})
+
+	It("is neither IsVersion6 nor HasVersion6 for a composite interface with no address of its own", func() {
+		conf := boshnet.StaticInterfaceConfiguration{Name: "bond0", Type: boshnet.InterfaceTypeBond, Slaves: []string{"eth0", "eth1"}}
+		Expect(conf.IsVersion6()).To(BeFalse())
+		Expect(conf.HasVersion6()).To(BeFalse())
+	})
+
+	It("uses DHCPv6 when the network requests it and has no static IP6", func() {
+		networks := boshsettings.Networks{
+			"default": boshsettings.Network{
+				Mac:    "aa:bb:cc:dd:ee:ff",
+				DHCPv6: true,
+			},
+		}
+
+		_, dhcp, err := creator.CreateInterfaceConfigurations(networks, map[string]string{"aa:bb:cc:dd:ee:ff": "eth0"}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dhcp).To(HaveLen(1))
+		Expect(dhcp[0].DHCPv6).To(BeTrue())
+	})
+})
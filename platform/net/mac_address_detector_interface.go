@@ -1,5 +1,14 @@
 package net
 
+// MACAddressDetector maps MAC addresses to interface names, split into
+// physical and virtual (or namespace-relocated) interfaces.
 type MACAddressDetector interface {
 	DetectMacAddresses() (map[string]string, map[string]string, error)
+
+	// SubscribeLinkChanges invokes onChange whenever an interface is added
+	// or removed, so callers can react to hot-plugged NICs instead of
+	// re-polling DetectMacAddresses. It blocks until stopCh is closed.
+	// Detectors that can't watch for changes (e.g. the sysfs fallback)
+	// return an error immediately instead of blocking forever.
+	SubscribeLinkChanges(stopCh <-chan struct{}, onChange func()) error
 }
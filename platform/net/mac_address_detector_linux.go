@@ -12,36 +12,47 @@ type linuxMacAddressDetector struct {
 	fs boshsys.FileSystem
 }
 
-func NewMacAddressDetector(fs boshsys.FileSystem) MACAddressDetector {
+// newSysfsMacAddressDetector builds the original /sys/class/net scanning
+// detector. It's kept around as the fallback for NewMacAddressDetector on
+// kernels where netlink isn't available.
+func newSysfsMacAddressDetector(fs boshsys.FileSystem) MACAddressDetector {
 	return linuxMacAddressDetector{
 		fs: fs,
 	}
 }
 
-func (d linuxMacAddressDetector) DetectMacAddresses() (map[string]string, error) {
-	addresses := map[string]string{}
+func (d linuxMacAddressDetector) DetectMacAddresses() (map[string]string, map[string]string, error) {
+	physicalAddresses := map[string]string{}
+	virtualAddresses := map[string]string{}
 
 	filePaths, err := d.fs.Glob("/sys/class/net/*")
 	if err != nil {
-		return addresses, bosherr.WrapError(err, "Getting file list from /sys/class/net")
+		return physicalAddresses, virtualAddresses, bosherr.WrapError(err, "Getting file list from /sys/class/net")
 	}
 
 	var macAddress string
 	for _, filePath := range filePaths {
-		isPhysicalDevice := d.fs.FileExists(path.Join(filePath, "device"))
-
-		if isPhysicalDevice {
-			macAddress, err = d.fs.ReadFileString(path.Join(filePath, "address"))
-			if err != nil {
-				return addresses, bosherr.WrapError(err, "Reading mac address from file")
-			}
+		macAddress, err = d.fs.ReadFileString(path.Join(filePath, "address"))
+		if err != nil {
+			return physicalAddresses, virtualAddresses, bosherr.WrapError(err, "Reading mac address from file")
+		}
 
-			macAddress = strings.Trim(macAddress, "\n")
+		macAddress = strings.Trim(macAddress, "\n")
+		interfaceName := path.Base(filePath)
 
-			interfaceName := path.Base(filePath)
-			addresses[macAddress] = interfaceName
+		if d.fs.FileExists(path.Join(filePath, "device")) {
+			physicalAddresses[macAddress] = interfaceName
+		} else {
+			virtualAddresses[macAddress] = interfaceName
 		}
 	}
 
-	return addresses, nil
+	return physicalAddresses, virtualAddresses, nil
+}
+
+// SubscribeLinkChanges always errors: /sys/class/net can be polled but has
+// no change-notification mechanism, so callers on this fallback path must
+// keep re-polling DetectMacAddresses themselves.
+func (d linuxMacAddressDetector) SubscribeLinkChanges(stopCh <-chan struct{}, onChange func()) error {
+	return bosherr.Error("Subscribing to link changes is not supported when falling back to /sys/class/net")
 }
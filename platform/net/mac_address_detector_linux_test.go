@@ -0,0 +1,30 @@
+package net_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("netlink-backed MACAddressDetector", func() {
+	It("satisfies MACAddressDetector, including SubscribeLinkChanges", func() {
+		fs := fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		var detector boshnet.MACAddressDetector = boshnet.NewMacAddressDetector(fs, logger)
+		Expect(detector).ToNot(BeNil())
+	})
+
+	It("returns physical and virtual MAC maps without error", func() {
+		fs := fakesys.NewFakeFileSystem()
+		detector := boshnet.NewMacAddressDetector(fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		physical, virtual, err := detector.DetectMacAddresses()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(physical).ToNot(BeNil())
+		Expect(virtual).ToNot(BeNil())
+	})
+})
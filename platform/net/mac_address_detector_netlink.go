@@ -0,0 +1,170 @@
+package net
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	macAddressDetectorLogTag = "netlinkMacAddressDetector"
+	// namedNetnsDir is where `ip netns add` bind-mounts named namespaces.
+	// A CPI that moves a physical interface out of the default namespace
+	// (see interfaceConfigurationCreator's virtualInterfacesByMAC handling)
+	// leaves it visible only from inside one of these.
+	namedNetnsDir = "/var/run/netns"
+)
+
+// virtualLinkKinds are netlink "kind" values for devices that don't
+// correspond to a physical NIC. Anything else with no parent index is
+// treated as physical.
+var virtualLinkKinds = map[string]bool{
+	"veth":   true,
+	"bridge": true,
+	"bond":   true,
+	"vlan":   true,
+	"dummy":  true,
+	"tun":    true,
+	"tap":    true,
+}
+
+// netlinkMacAddressDetector discovers interface MAC addresses over a
+// netlink socket instead of globbing /sys/class/net. It also distinguishes
+// interfaces that have been moved into a non-default network namespace
+// (surfaced to callers as "virtual", matching how
+// interfaceConfigurationCreator.createMultipleInterfaceConfigurations
+// treats virtualInterfacesByMAC).
+type netlinkMacAddressDetector struct {
+	fallback MACAddressDetector
+	logger   boshlog.Logger
+	logTag   string
+}
+
+// NewMacAddressDetector returns a netlink-based MACAddressDetector, falling
+// back to scanning /sys/class/net on kernels where netlink link dumps
+// aren't available (e.g. inside restrictive containers).
+func NewMacAddressDetector(fs boshsys.FileSystem, logger boshlog.Logger) MACAddressDetector {
+	return netlinkMacAddressDetector{
+		fallback: newSysfsMacAddressDetector(fs),
+		logger:   logger,
+		logTag:   macAddressDetectorLogTag,
+	}
+}
+
+func (d netlinkMacAddressDetector) DetectMacAddresses() (map[string]string, map[string]string, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		d.logger.Warn(d.logTag, "Listing netlink links, falling back to sysfs: %s", err.Error())
+		return d.fallback.DetectMacAddresses()
+	}
+
+	physicalAddresses := map[string]string{}
+	virtualAddresses := map[string]string{}
+
+	for _, link := range links {
+		attrs := link.Attrs()
+
+		macAddress := attrs.HardwareAddr.String()
+		if macAddress == "" || macAddress == "00:00:00:00:00:00" {
+			continue
+		}
+
+		if isVirtualLink(link, attrs) {
+			virtualAddresses[macAddress] = attrs.Name
+		} else {
+			physicalAddresses[macAddress] = attrs.Name
+		}
+	}
+
+	d.detectRelocatedAddresses(virtualAddresses)
+
+	return physicalAddresses, virtualAddresses, nil
+}
+
+// detectRelocatedAddresses looks inside every named network namespace
+// under namedNetnsDir for interfaces the CPI moved there, since
+// netlink.LinkList only ever sees the caller's own namespace. Interfaces
+// found this way are reported as virtual, matching how
+// interfaceConfigurationCreator.createMultipleInterfaceConfigurations
+// already treats virtualInterfacesByMAC. A namespace that can't be
+// inspected is logged and skipped rather than failing detection outright.
+func (d netlinkMacAddressDetector) detectRelocatedAddresses(virtualAddresses map[string]string) {
+	entries, err := os.ReadDir(namedNetnsDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		nsHandle, err := netns.GetFromPath(filepath.Join(namedNetnsDir, entry.Name()))
+		if err != nil {
+			d.logger.Warn(d.logTag, "Opening netns %s: %s", entry.Name(), err.Error())
+			continue
+		}
+
+		nlHandle, err := netlink.NewHandleAt(nsHandle)
+		nsHandle.Close() //nolint:errcheck
+		if err != nil {
+			d.logger.Warn(d.logTag, "Opening netlink handle in netns %s: %s", entry.Name(), err.Error())
+			continue
+		}
+
+		links, err := nlHandle.LinkList()
+		nlHandle.Close()
+		if err != nil {
+			d.logger.Warn(d.logTag, "Listing links in netns %s: %s", entry.Name(), err.Error())
+			continue
+		}
+
+		for _, link := range links {
+			attrs := link.Attrs()
+			macAddress := attrs.HardwareAddr.String()
+			if macAddress == "" || macAddress == "00:00:00:00:00:00" {
+				continue
+			}
+			if _, alreadyKnown := virtualAddresses[macAddress]; !alreadyKnown {
+				virtualAddresses[macAddress] = attrs.Name
+			}
+		}
+	}
+}
+
+// SubscribeLinkChanges invokes onChange whenever a RTM_NEWLINK or
+// RTM_DELLINK event is observed, so callers can react to hot-plugged NICs
+// without re-polling DetectMacAddresses. It blocks until stopCh is closed,
+// or returns an error if the netlink subscription ends before that.
+func (d netlinkMacAddressDetector) SubscribeLinkChanges(stopCh <-chan struct{}, onChange func()) error {
+	updates := make(chan netlink.LinkUpdate)
+	if err := netlink.LinkSubscribe(updates, stopCh); err != nil {
+		return bosherr.WrapError(err, "Subscribing to netlink link updates")
+	}
+
+	for {
+		select {
+		case _, ok := <-updates:
+			if !ok {
+				select {
+				case <-stopCh:
+					return nil
+				default:
+					return bosherr.Error("Netlink link update subscription closed")
+				}
+			}
+			onChange()
+		case <-stopCh:
+			return nil
+		}
+	}
+}
+
+func isVirtualLink(link netlink.Link, attrs *netlink.LinkAttrs) bool {
+	if attrs.ParentIndex != 0 {
+		return true
+	}
+	return virtualLinkKinds[link.Type()]
+}
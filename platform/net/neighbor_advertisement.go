@@ -0,0 +1,92 @@
+package net
+
+import (
+	"net"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// sendUnsolicitedNeighborAdvertisement announces addr on ifaceName over a
+// raw ICMPv6 socket: the NDP equivalent of a gratuitous ARP, telling every
+// neighbor on the segment to point addr's entry at this interface's MAC
+// without waiting to be asked (RFC 4861 section 7.2.6).
+func sendUnsolicitedNeighborAdvertisement(ifaceName, addr string) error {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Looking up interface '%s'", ifaceName)
+	}
+
+	targetIP := net.ParseIP(addr)
+	if targetIP == nil || targetIP.To16() == nil {
+		return bosherr.Errorf("Parsing IPv6 address '%s'", addr)
+	}
+
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return bosherr.WrapError(err, "Opening ICMPv6 socket")
+	}
+	defer conn.Close() //nolint:errcheck
+
+	if err := conn.IPv6PacketConn().SetMulticastInterface(iface); err != nil {
+		return bosherr.WrapErrorf(err, "Binding ICMPv6 socket to '%s'", ifaceName)
+	}
+
+	// RFC 4861 section 7.1.2 requires a Neighbor Advertisement's IP Hop
+	// Limit to be 255; compliant receivers silently discard anything else,
+	// since that's what stops an off-link attacker from spoofing one.
+	if err := conn.IPv6PacketConn().SetHopLimit(255); err != nil {
+		return bosherr.WrapErrorf(err, "Setting hop limit on '%s'", ifaceName)
+	}
+	if err := conn.IPv6PacketConn().SetMulticastHopLimit(255); err != nil {
+		return bosherr.WrapErrorf(err, "Setting multicast hop limit on '%s'", ifaceName)
+	}
+
+	msg := icmp.Message{
+		Type: ipv6.ICMPTypeNeighborAdvertisement,
+		Code: 0,
+		Body: &neighborAdvertisementBody{
+			targetIP:  targetIP,
+			targetMAC: iface.HardwareAddr,
+		},
+	}
+
+	wireBytes, err := msg.Marshal(nil)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling neighbor advertisement")
+	}
+
+	dst := &net.IPAddr{IP: net.ParseIP("ff02::1"), Zone: ifaceName}
+	if _, err := conn.WriteTo(wireBytes, dst); err != nil {
+		return bosherr.WrapErrorf(err, "Sending neighbor advertisement on '%s'", ifaceName)
+	}
+
+	return nil
+}
+
+// neighborAdvertisementBody is the RFC 4861 Neighbor Advertisement payload
+// (override flag, target address, and a Target Link-Layer Address option)
+// that follows the ICMPv6 type/code/checksum header icmp.Message already
+// writes for us.
+type neighborAdvertisementBody struct {
+	targetIP  net.IP
+	targetMAC net.HardwareAddr
+}
+
+func (na *neighborAdvertisementBody) Len(_ int) int {
+	return 4 + 16 + 2 + len(na.targetMAC)
+}
+
+func (na *neighborAdvertisementBody) Marshal(_ int) ([]byte, error) {
+	const overrideFlag = 0x20
+
+	b := make([]byte, 0, na.Len(0))
+	b = append(b, overrideFlag, 0, 0, 0)
+	b = append(b, na.targetIP.To16()...)
+	// Target Link-Layer Address option: type 2, length in units of 8 bytes.
+	b = append(b, 2, byte((2+len(na.targetMAC))/8))
+	b = append(b, na.targetMAC...)
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package net
+
+import (
+	"sync"
+
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const postConfigureBroadcasterLogTag = "PostConfigureBroadcaster"
+
+// PostConfigureBroadcaster announces every statically configured address
+// (gratuitous ARP for IPv4, an unsolicited neighbor advertisement for
+// IPv6) once CreateInterfaceConfigurations has returned, so switches
+// relearn the MAC for an address this agent's VM just inherited from a
+// dead peer instead of keeping a stale ARP/NDP cache entry for 30+
+// seconds.
+type PostConfigureBroadcaster interface {
+	Broadcast(staticConfigs StaticInterfaceConfigurations)
+}
+
+type postConfigureBroadcaster struct {
+	addressBroadcaster bosharp.AddressBroadcaster
+
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewPostConfigureBroadcaster(addressBroadcaster bosharp.AddressBroadcaster, logger boshlog.Logger) PostConfigureBroadcaster {
+	return &postConfigureBroadcaster{
+		addressBroadcaster: addressBroadcaster,
+
+		logger: logger,
+		logTag: postConfigureBroadcasterLogTag,
+	}
+}
+
+func (b *postConfigureBroadcaster) Broadcast(staticConfigs StaticInterfaceConfigurations) {
+	var v4Addresses []bosharp.InterfaceAddress
+	var v6Announcements []struct{ ifaceName, address string }
+
+	for _, conf := range staticConfigs {
+		if len(conf.Address) > 0 && !conf.IsVersion6() {
+			v4Addresses = append(v4Addresses, bosharp.NewSimpleInterfaceAddress(conf.Name, conf.Address))
+		}
+
+		if conf.HasVersion6() {
+			address6 := conf.Address6
+			if len(address6) == 0 {
+				address6 = conf.Address
+			}
+			if len(address6) > 0 {
+				v6Announcements = append(v6Announcements, struct{ ifaceName, address string }{conf.Name, address6})
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	if len(v4Addresses) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.addressBroadcaster.BroadcastMACAddresses(v4Addresses)
+		}()
+	}
+
+	for _, announcement := range v6Announcements {
+		announcement := announcement
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sendUnsolicitedNeighborAdvertisement(announcement.ifaceName, announcement.address); err != nil {
+				b.logger.Error(b.logTag, "Sending neighbor advertisement for '%s' on '%s': %s", announcement.address, announcement.ifaceName, err.Error())
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,50 @@
+package net_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type fakeAddressBroadcaster struct {
+	broadcasted []bosharp.InterfaceAddress
+}
+
+func (b *fakeAddressBroadcaster) BroadcastMACAddresses(addresses []bosharp.InterfaceAddress) {
+	b.broadcasted = append(b.broadcasted, addresses...)
+}
+
+var _ = Describe("postConfigureBroadcaster", func() {
+	var (
+		addressBroadcaster *fakeAddressBroadcaster
+		broadcaster        boshnet.PostConfigureBroadcaster
+	)
+
+	BeforeEach(func() {
+		addressBroadcaster = &fakeAddressBroadcaster{}
+		broadcaster = boshnet.NewPostConfigureBroadcaster(addressBroadcaster, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("broadcasts every IPv4 static address through the arp.AddressBroadcaster", func() {
+		broadcaster.Broadcast(boshnet.StaticInterfaceConfigurations{
+			{Name: "eth0", Address: "1.2.3.4", Network: "1.2.3.0", Broadcast: "1.2.3.255"},
+		})
+
+		Expect(addressBroadcaster.broadcasted).To(HaveLen(1))
+		ip, err := addressBroadcaster.broadcasted[0].GetIP()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ip).To(Equal("1.2.3.4"))
+		Expect(addressBroadcaster.broadcasted[0].GetInterfaceName()).To(Equal("eth0"))
+	})
+
+	It("does not send an IPv4 gratuitous ARP for an IPv6-only address", func() {
+		broadcaster.Broadcast(boshnet.StaticInterfaceConfigurations{
+			{Name: "eth0", Address6: "2001:db8::1"},
+		})
+
+		Expect(addressBroadcaster.broadcasted).To(BeEmpty())
+	})
+})
@@ -0,0 +1,120 @@
+package net
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GenerateUbuntuInterfacesConfig renders the /etc/network/interfaces
+// stanzas for the given configurations, in the order provided (callers
+// should pass the output of CreateInterfaceConfigurations, which already
+// runs sortForComposition). It's the rendering half of
+// interfaceConfigurationCreator's output: everything
+// CreateInterfaceConfigurations computes but doesn't itself know how to
+// write to disk.
+func GenerateUbuntuInterfacesConfig(staticConfigs StaticInterfaceConfigurations, dhcpConfigs DHCPInterfaceConfigurations) string {
+	var buf bytes.Buffer
+
+	for _, conf := range dhcpConfigs {
+		buf.WriteString(renderUbuntuDHCPStanza(conf))
+	}
+
+	for _, conf := range staticConfigs {
+		buf.WriteString(renderUbuntuStaticStanza(conf))
+	}
+
+	return buf.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func renderUbuntuDHCPStanza(conf DHCPInterfaceConfiguration) string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "auto %s\n", conf.Name)
+	if len(conf.Address) > 0 {
+		fmt.Fprintf(&buf, "iface %s inet static\n", conf.Name)
+		fmt.Fprintf(&buf, "    address %s\n", conf.Address)
+	} else {
+		fmt.Fprintf(&buf, "iface %s inet dhcp\n", conf.Name)
+	}
+	if conf.DHCPv6 {
+		fmt.Fprintf(&buf, "iface %s inet6 auto\n", conf.Name)
+	}
+	buf.WriteString("\n")
+
+	return buf.String()
+}
+
+func renderUbuntuStaticStanza(conf StaticInterfaceConfiguration) string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "auto %s\n", conf.Name)
+
+	switch {
+	case len(conf.Address) > 0:
+		fmt.Fprintf(&buf, "iface %s inet static\n", conf.Name)
+		fmt.Fprintf(&buf, "    address %s\n", conf.Address)
+		fmt.Fprintf(&buf, "    network %s\n", conf.Network)
+		fmt.Fprintf(&buf, "    netmask %s\n", conf.Netmask)
+		fmt.Fprintf(&buf, "    broadcast %s\n", conf.Broadcast)
+		if conf.IsDefaultForGateway {
+			fmt.Fprintf(&buf, "    gateway %s\n", conf.Gateway)
+		}
+		for _, route := range conf.PostUpRoutes {
+			fmt.Fprintf(&buf, "    post-up route add -net %s netmask %s gw %s\n", route.Destination, route.Netmask, route.Gateway)
+		}
+		writeUbuntuCompositionOptions(&buf, conf)
+	case len(conf.Address6) == 0:
+		// No address at all: either a bare member interface or a
+		// composite whose address lives on a vlan/bridge above it.
+		// Still bring it up so bond-slaves/bridge_ports/vlan-raw-device
+		// on the interfaces that depend on it have something to attach to.
+		fmt.Fprintf(&buf, "iface %s inet manual\n", conf.Name)
+		writeUbuntuCompositionOptions(&buf, conf)
+	}
+
+	if len(conf.Address6) > 0 {
+		fmt.Fprintf(&buf, "iface %s inet6 static\n", conf.Name)
+		fmt.Fprintf(&buf, "    address %s\n", conf.Address6)
+		fmt.Fprintf(&buf, "    netmask %s\n", conf.Netmask6OrLen())
+		if len(conf.Gateway6) > 0 {
+			fmt.Fprintf(&buf, "    gateway %s\n", conf.Gateway6)
+		}
+		if len(conf.Address) == 0 {
+			writeUbuntuCompositionOptions(&buf, conf)
+		}
+	}
+
+	buf.WriteString("\n")
+
+	return buf.String()
+}
+
+// writeUbuntuCompositionOptions appends the bond/vlan/bridge sub-options
+// (see StaticInterfaceConfiguration.Type) to the iface stanza that was
+// just written for conf.
+func writeUbuntuCompositionOptions(buf *bytes.Buffer, conf StaticInterfaceConfiguration) {
+	switch conf.Type {
+	case InterfaceTypeBond:
+		fmt.Fprintf(buf, "    bond-slaves %s\n", strings.Join(conf.Slaves, " "))
+		for _, opt := range sortedKeys(conf.BondOptions) {
+			fmt.Fprintf(buf, "    bond-%s %s\n", opt, conf.BondOptions[opt])
+		}
+	case InterfaceTypeVlan:
+		if len(conf.Slaves) > 0 {
+			fmt.Fprintf(buf, "    vlan-raw-device %s\n", conf.Slaves[0])
+		}
+	case InterfaceTypeBridge:
+		fmt.Fprintf(buf, "    bridge_ports %s\n", strings.Join(conf.Slaves, " "))
+	}
+}
@@ -0,0 +1,77 @@
+package net_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+)
+
+var _ = Describe("GenerateUbuntuInterfacesConfig", func() {
+	It("renders both the IPv4 and IPv6 stanzas for a dual-stack static interface", func() {
+		config := boshnet.GenerateUbuntuInterfacesConfig(boshnet.StaticInterfaceConfigurations{
+			{
+				Name:                "eth0",
+				Address:             "1.2.3.4",
+				Netmask:             "255.255.255.0",
+				Network:             "1.2.3.0",
+				Broadcast:           "1.2.3.255",
+				Gateway:             "1.2.3.1",
+				IsDefaultForGateway: true,
+				Address6:            "2001:db8::1",
+				Netmask6:            "64",
+				Gateway6:            "2001:db8::ffff",
+			},
+		}, nil)
+
+		Expect(config).To(ContainSubstring("iface eth0 inet static"))
+		Expect(config).To(ContainSubstring("address 1.2.3.4"))
+		Expect(config).To(ContainSubstring("gateway 1.2.3.1"))
+		Expect(config).To(ContainSubstring("iface eth0 inet6 static"))
+		Expect(config).To(ContainSubstring("address 2001:db8::1"))
+		Expect(config).To(ContainSubstring("netmask 64"))
+		Expect(config).To(ContainSubstring("gateway 2001:db8::ffff"))
+	})
+
+	It("renders bond-slaves/bond-options for a bond and a manual stanza for its members", func() {
+		config := boshnet.GenerateUbuntuInterfacesConfig(boshnet.StaticInterfaceConfigurations{
+			{Name: "eth0", MemberOf: "bond0"},
+			{Name: "eth1", MemberOf: "bond0"},
+			{
+				Name:                "bond0",
+				Address:             "10.0.0.5",
+				Netmask:             "255.255.255.0",
+				Network:             "10.0.0.0",
+				Broadcast:           "10.0.0.255",
+				Type:                boshnet.InterfaceTypeBond,
+				Slaves:              []string{"eth0", "eth1"},
+				BondOptions:         map[string]string{"mode": "active-backup"},
+			},
+		}, nil)
+
+		Expect(config).To(ContainSubstring("iface eth0 inet manual"))
+		Expect(config).To(ContainSubstring("iface eth1 inet manual"))
+		Expect(config).To(ContainSubstring("iface bond0 inet static"))
+		Expect(config).To(ContainSubstring("bond-slaves eth0 eth1"))
+		Expect(config).To(ContainSubstring("bond-mode active-backup"))
+	})
+
+	It("renders vlan-raw-device for a vlan and bridge_ports for a bridge", func() {
+		config := boshnet.GenerateUbuntuInterfacesConfig(boshnet.StaticInterfaceConfigurations{
+			{Name: "eth0.10", Address6: "2001:db8::1", Netmask6: "64", Type: boshnet.InterfaceTypeVlan, Slaves: []string{"eth0"}, VlanID: 10},
+			{Name: "br0", Address: "10.0.0.1", Type: boshnet.InterfaceTypeBridge, Slaves: []string{"eth1", "eth2"}},
+		}, nil)
+
+		Expect(config).To(ContainSubstring("vlan-raw-device eth0"))
+		Expect(config).To(ContainSubstring("bridge_ports eth1 eth2"))
+	})
+
+	It("renders an inet6 auto stanza for DHCPv6", func() {
+		config := boshnet.GenerateUbuntuInterfacesConfig(nil, boshnet.DHCPInterfaceConfigurations{
+			{Name: "eth0", DHCPv6: true},
+		})
+
+		Expect(config).To(ContainSubstring("iface eth0 inet dhcp"))
+		Expect(config).To(ContainSubstring("iface eth0 inet6 auto"))
+	})
+})
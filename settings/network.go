@@ -0,0 +1,96 @@
+package settings
+
+// Route is a single post-up route to add once an interface is configured.
+type Route struct {
+	Destination string
+	Gateway     string
+	Netmask     string
+}
+
+type Routes []Route
+
+// Network describes the settings the CPI/director hand the agent for a
+// single network interface (matched to a device by Mac, or by Alias when
+// several interfaces share a network).
+type Network struct {
+	Mac   string
+	Alias string
+
+	IP      string
+	Netmask string
+	Gateway string
+
+	// IP6/Netmask6/Gateway6 are the IPv6 counterparts, set when the
+	// interface is dual-stack (an IPv4 and an IPv6 address configured at
+	// once) or IPv6-only (IP left empty).
+	IP6      string
+	Netmask6 string
+	Gateway6 string
+
+	// DHCPv6 requests stateful DHCPv6/SLAAC for this interface when the
+	// director hasn't handed down a static IP6 (see IsDHCPv6). It's carried
+	// only onto an interface that is configured through DHCP; an interface
+	// that gets a static configuration (e.g. a static IP) gets no dynamic
+	// IPv6 from it.
+	DHCPv6 bool
+
+	Routes  Routes
+	Default []string
+
+	// Type selects a composite interface (bond/vlan/bridge) built out of
+	// other interfaces rather than a single physical NIC. Empty means a
+	// plain interface. Mirrors platform/net.InterfaceType* constants.
+	Type        string
+	Slaves      []string
+	VlanID      int
+	BondOptions map[string]string
+
+	CloudProperties map[string]interface{}
+}
+
+// IsDHCP returns true when no static address was given at all (neither IP
+// nor IP6), so the interface should be brought up with DHCP instead.
+func (n Network) IsDHCP() bool {
+	return n.IP == "" && n.IP6 == ""
+}
+
+// IsDHCPv6 returns true when the network wants IPv6 connectivity brought
+// up dynamically (stateful DHCPv6 or SLAAC) rather than via a static IP6.
+func (n Network) IsDHCPv6() bool {
+	return n.DHCPv6 && n.IP6 == ""
+}
+
+// IsDefaultFor returns true if the network is default for a given network
+// property (e.g. "gateway", "dns").
+func (n Network) IsDefaultFor(category string) bool {
+	for _, c := range n.Default {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+type Networks map[string]Network
+
+// NetworkForMac returns the network settings matching the given MAC
+// address, if any.
+func (networks Networks) NetworkForMac(mac string) (Network, bool) {
+	for _, network := range networks {
+		if network.Mac == mac {
+			return network, true
+		}
+	}
+	return Network{}, false
+}
+
+// HasInterfaceAlias returns true if any network is addressed by Alias
+// rather than by Mac.
+func (networks Networks) HasInterfaceAlias() bool {
+	for _, network := range networks {
+		if network.Alias != "" {
+			return true
+		}
+	}
+	return false
+}